@@ -0,0 +1,69 @@
+// Package v1 contains the tlser.io/v1 API group, currently just
+// TLSCertificate: a CRD mirroring the binary's command-line flags so a
+// single tlser controller can manage many certificates.
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TLSCertificateSpec mirrors the flags tlser accepts when run against a
+// single certificate: subject, SANs, validity, and where to read the CA
+// from and write the result to.
+type TLSCertificateSpec struct {
+	// Subject is the certificate Subject Common Name.
+	Subject string `json:"subject"`
+	// DNS is the list of DNS subject alternative names.
+	DNS []string `json:"dns,omitempty"`
+	// IP is the list of IP subject alternative names.
+	IP []string `json:"ip,omitempty"`
+	// ExpireDays is the certificate validity period, in days.
+	ExpireDays int `json:"expireDays,omitempty"`
+	// SecretRef names the Secret this certificate is synced to, in the
+	// same namespace as the TLSCertificate unless otherwise specified.
+	SecretRef SecretReference `json:"secretRef"`
+	// CARef names the Secret holding the CA certificate and key used to
+	// sign this certificate.
+	CARef SecretReference `json:"caRef"`
+	// Labels are stamped onto the generated Secret.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SecretReference points at a Secret, optionally in another namespace.
+type SecretReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// TLSCertificateStatus reports the outcome of the most recent reconcile.
+type TLSCertificateStatus struct {
+	// NotAfter is the expiry of the certificate currently in the target
+	// Secret, if any.
+	NotAfter string `json:"notAfter,omitempty"`
+	// ObservedGeneration is the .metadata.generation last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// TLSCertificate asks the tlser controller to keep a Secret populated with
+// a certificate matching Spec, signed by CARef, rotating it on expiry or
+// input drift.
+type TLSCertificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TLSCertificateSpec   `json:"spec"`
+	Status TLSCertificateStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TLSCertificateList is a list of TLSCertificate.
+type TLSCertificateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []TLSCertificate `json:"items"`
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+// certificate pairs a parsed x509 certificate with its private key. It holds
+// the CA (the "signer"); leaf certificates read back out of a Secret are
+// parsed into a plain *x509.Certificate by parseLeafCert instead.
+type certificate struct {
+	cert *x509.Certificate
+	key  *rsa.PrivateKey
+}
+
+// parseCertPair decodes a DER-encoded certificate and private key (as
+// produced by readPem) into a certificate.
+func parseCertPair(certBytes, keyBytes []byte) (certificate, error) {
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return certificate{}, fmt.Errorf("unable to parse certificate: %w", err)
+	}
+
+	key, err := parseRSAPrivateKey(keyBytes)
+	if err != nil {
+		return certificate{}, err
+	}
+
+	return certificate{cert: cert, key: key}, nil
+}
+
+// parseRSAPrivateKey decodes a DER-encoded RSA private key in either PKCS#1
+// or PKCS#8 form; Vault, for instance, returns PKCS#8.
+func parseRSAPrivateKey(keyBytes []byte) (*rsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS1PrivateKey(keyBytes)
+	if err != nil {
+		key8, err8 := x509.ParsePKCS8PrivateKey(keyBytes)
+		if err8 != nil {
+			return nil, fmt.Errorf("unable to parse private key: %w", err)
+		}
+		rsaKey, ok := key8.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is not an RSA key")
+		}
+		key = rsaKey
+	}
+	return key, nil
+}
+
+// generateSignedCert issues a leaf certificate for subject, signed by
+// signer, and returns the PEM-encoded certificate and private key.
+func generateSignedCert(subject string, ips, dnsNames []string, daysValid int, key *rsa.PrivateKey, signer certificate) (string, string, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", fmt.Errorf("unable to generate serial number: %w", err)
+	}
+
+	var ipAddrs []net.IP
+	for _, s := range ips {
+		if parsed := net.ParseIP(s); parsed != nil {
+			ipAddrs = append(ipAddrs, parsed)
+		}
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: subject},
+		DNSNames:     dnsNames,
+		IPAddresses:  ipAddrs,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().AddDate(0, 0, daysValid),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.cert, &key.PublicKey, signer.key)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to sign certificate: %w", err)
+	}
+
+	certPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	return certPEM, keyPEM, nil
+}
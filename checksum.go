@@ -0,0 +1,67 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// inputChecksumAnnotation records the checksum of the inputs that produced
+// the certificate currently in a Secret, so a later sync can tell whether
+// anything that would change the certificate has actually changed.
+const inputChecksumAnnotation = "tlser.io/input-checksum"
+
+// notAfterAnnotation records the leaf certificate's expiry directly, as
+// RFC3339. Not every -format writes a tls.crt key the checksum short
+// circuit in sync() could otherwise parse back out (pkcs12/jks/opaque with
+// custom key names all leave it unset), so expiry is tracked independently
+// of where, or whether, the PEM ends up in the Secret.
+const notAfterAnnotation = "tlser.io/not-after"
+
+// inputChecksum hashes the normalized set of inputs that determine the
+// generated certificate and Secret layout, so unrelated ticks can skip
+// regeneration when nothing has changed and the cert isn't due for
+// renewal.
+func inputChecksum(subject string, dns, ip []string, daysValid int, lbls labels, caFingerprint string, formats formatList, p12Password, opaqueCertKey, opaqueKeyKey string) string {
+	dns = append([]string(nil), dns...)
+	ip = append([]string(nil), ip...)
+	sort.Strings(dns)
+	sort.Strings(ip)
+
+	sortedFormats := append([]string(nil), formats...)
+	sort.Strings(sortedFormats)
+
+	var labelKeys []string
+	for k := range lbls {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+
+	var labelPairs []string
+	for _, k := range labelKeys {
+		labelPairs = append(labelPairs, k+"="+lbls[k])
+	}
+
+	h := sha256.New()
+	h.Write([]byte(subject))
+	h.Write([]byte(strings.Join(dns, ",")))
+	h.Write([]byte(strings.Join(ip, ",")))
+	h.Write([]byte(strconv.Itoa(daysValid)))
+	h.Write([]byte(strings.Join(labelPairs, ",")))
+	h.Write([]byte(caFingerprint))
+	h.Write([]byte(strings.Join(sortedFormats, ",")))
+	h.Write([]byte(p12Password))
+	h.Write([]byte(opaqueCertKey))
+	h.Write([]byte(opaqueKeyKey))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// certFingerprint is a stable SHA256 fingerprint of a CA certificate, used
+// as an input to inputChecksum so CA rotation forces a resync.
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	tlserv1 "github.com/isabella232/tlser/api/v1"
+)
+
+// crdReconciler watches TLSCertificate custom resources cluster-wide and
+// reconciles one Secret per CR, instantiating a syncer for each. This lets
+// a single tlser deployment manage many certificates instead of the one
+// driven by command-line flags.
+type crdReconciler struct {
+	client client.Client
+}
+
+func (r *crdReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	var cr tlserv1.TLSCertificate
+	if err := r.client.Get(ctx, req.NamespacedName, &cr); err != nil {
+		if apierrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("unable to get TLSCertificate %s: %w", req.NamespacedName, err)
+	}
+
+	secretNs := cr.Spec.SecretRef.Namespace
+	if secretNs == "" {
+		secretNs = cr.Namespace
+	}
+
+	daysValid := cr.Spec.ExpireDays
+	if daysValid == 0 {
+		daysValid = 60
+	}
+
+	caNs := cr.Spec.CARef.Namespace
+	if caNs == "" {
+		caNs = cr.Namespace
+	}
+
+	target := identifier{name: cr.Spec.SecretRef.Name, namespace: secretNs}
+	sync := syncer{
+		secrets:   crClient{client: r.client},
+		id:        target,
+		subject:   cr.Spec.Subject,
+		ip:        cr.Spec.IP,
+		dns:       cr.Spec.DNS,
+		daysValid: daysValid,
+		labels:    cr.Spec.Labels,
+		getSigner: func() (Signer, error) {
+			return SecretSigner{
+				secrets: crClient{client: r.client},
+				id:      identifier{name: cr.Spec.CARef.Name, namespace: caNs},
+			}, nil
+		},
+	}
+
+	leaf, err := sync.sync()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to sync certs for %s: %w", req.NamespacedName, err)
+	}
+
+	if err := r.updateStatus(ctx, &cr, leaf); err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to update status for %s: %w", req.NamespacedName, err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// updateStatus stamps leaf's expiry, plus the generation just reconciled,
+// onto the TLSCertificate's status subresource. leaf comes from sync's own
+// return value rather than a fresh read of the target Secret: right after
+// a create, the manager's cache may not yet have observed it, so a read
+// through r.client here would intermittently see NotFound or a stale
+// object and requeue every first reconcile.
+func (r *crdReconciler) updateStatus(ctx context.Context, cr *tlserv1.TLSCertificate, leaf *x509.Certificate) error {
+	if leaf == nil {
+		return fmt.Errorf("no leaf certificate available after sync")
+	}
+
+	cr.Status.NotAfter = leaf.NotAfter.Format("2006-01-02T15:04:05Z07:00")
+	cr.Status.ObservedGeneration = cr.Generation
+	return r.client.Status().Update(ctx, cr)
+}
+
+// crClient adapts a controller-runtime client.Client to the secretsClient
+// interface the syncer expects, so CRD mode can reuse the same sync logic
+// as flag-driven mode.
+type crClient struct {
+	client client.Client
+}
+
+func (a crClient) getSecret(id identifier) (*secret, error) {
+	var s secret
+	if err := a.client.Get(context.Background(), types.NamespacedName{Namespace: id.namespace, Name: id.name}, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (a crClient) setSecret(s *secret, update bool) error {
+	if update {
+		return a.client.Update(context.Background(), s)
+	}
+	return a.client.Create(context.Background(), s)
+}
+
+// runCRDController starts a manager that watches TLSCertificate resources
+// cluster-wide and reconciles a Secret per CR. It blocks until the manager
+// stops or errors.
+func runCRDController(mgr manager.Manager) error {
+	if err := tlserv1.AddToScheme(mgr.GetScheme()); err != nil {
+		return fmt.Errorf("unable to register tlser.io/v1 scheme: %w", err)
+	}
+
+	r := &crdReconciler{client: mgr.GetClient()}
+	if err := builder.ControllerManagedBy(mgr).For(&tlserv1.TLSCertificate{}).Complete(r); err != nil {
+		return fmt.Errorf("unable to build controller: %w", err)
+	}
+
+	return mgr.Start(context.Background())
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	keystore "github.com/pavlo-v-chernykh/keystore-go/v4"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// formatList accumulates repeated -format flags naming which keys should
+// end up in the generated Secret.
+type formatList []string
+
+func (f *formatList) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *formatList) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// buildSecretData assembles the Secret Data and Type for the leaf
+// certificate/key pair, in whatever formats were requested. With no
+// formats requested it reproduces the original kubernetes.io/tls layout.
+//
+// The Secret Type stays kubernetes.io/tls as long as the result still has
+// the standard tls.crt/tls.key pair at the standard keys - "fullchain"
+// only adds ca.crt alongside them, and pkcs12/jks only add keystore
+// entries. "opaque" switches it to Opaque unconditionally: that format
+// exists specifically to let a key be renamed off tls.crt/tls.key (or
+// consumed by something that isn't expecting a TLS-typed Secret), so it
+// shouldn't silently keep the TLS type just because the default key names
+// happened to be left alone.
+func (s syncer) buildSecretData(certPEM, keyPEM string, caPEM []byte) (map[string][]byte, corev1.SecretType, error) {
+	if len(s.formats) == 0 {
+		return map[string][]byte{
+			corev1.TLSCertKey:       []byte(certPEM),
+			corev1.TLSPrivateKeyKey: []byte(keyPEM),
+		}, corev1.SecretTypeTLS, nil
+	}
+
+	data := map[string][]byte{}
+	secretType := corev1.SecretTypeOpaque
+
+	leafBlock, _ := pem.Decode([]byte(certPEM))
+	if leafBlock == nil {
+		return nil, "", fmt.Errorf("unable to decode generated leaf certificate")
+	}
+	leaf, err := x509.ParseCertificate(leafBlock.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse generated leaf certificate: %w", err)
+	}
+
+	keyBlock, _ := pem.Decode([]byte(keyPEM))
+	if keyBlock == nil {
+		return nil, "", fmt.Errorf("unable to decode generated private key")
+	}
+	key, err := parseRSAPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to parse generated private key: %w", err)
+	}
+
+	usesOpaqueFormat := false
+	for _, format := range s.formats {
+		switch format {
+		case "fullchain":
+			data[corev1.TLSCertKey] = []byte(certPEM + string(caPEM))
+			data[corev1.TLSPrivateKeyKey] = []byte(keyPEM)
+			data["ca.crt"] = caPEM
+
+		case "opaque":
+			usesOpaqueFormat = true
+			certKey, keyKey := s.opaqueCertKey, s.opaqueKeyKey
+			if certKey == "" {
+				certKey = corev1.TLSCertKey
+			}
+			if keyKey == "" {
+				keyKey = corev1.TLSPrivateKeyKey
+			}
+			data[certKey] = []byte(certPEM)
+			data[keyKey] = []byte(keyPEM)
+
+		case "pkcs12":
+			p12, err := pkcs12.Encode(rand.Reader, key, leaf, nil, s.p12Password)
+			if err != nil {
+				return nil, "", fmt.Errorf("unable to build PKCS#12 keystore: %w", err)
+			}
+			data["keystore.p12"] = p12
+
+		case "jks":
+			jks, err := buildJKS(leaf, key, s.p12Password)
+			if err != nil {
+				return nil, "", fmt.Errorf("unable to build JKS keystore: %w", err)
+			}
+			data["keystore.jks"] = jks
+
+		default:
+			return nil, "", fmt.Errorf("unknown -format %q", format)
+		}
+	}
+
+	if len(data) == 0 {
+		data[corev1.TLSCertKey] = []byte(certPEM)
+		data[corev1.TLSPrivateKeyKey] = []byte(keyPEM)
+	}
+
+	_, hasCert := data[corev1.TLSCertKey]
+	_, hasKey := data[corev1.TLSPrivateKeyKey]
+	if hasCert && hasKey && !usesOpaqueFormat {
+		secretType = corev1.SecretTypeTLS
+	}
+
+	return data, secretType, nil
+}
+
+// buildJKS encodes a leaf certificate and private key into a Java KeyStore,
+// protected by password, under the alias "tlser".
+func buildJKS(leaf *x509.Certificate, key *rsa.PrivateKey, password string) ([]byte, error) {
+	ks := keystore.New()
+
+	entry := keystore.PrivateKeyEntry{
+		CreationTime: leaf.NotBefore,
+		PrivateKey:   x509.MarshalPKCS1PrivateKey(key),
+		CertificateChain: []keystore.Certificate{
+			{Type: "X509", Content: leaf.Raw},
+		},
+	}
+	if err := ks.SetPrivateKeyEntry("tlser", entry, []byte(password)); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := ks.Store(&buf, []byte(password)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// labels accumulates repeated -label key=value flags into a map suitable
+// for stamping onto a generated Secret.
+type labels map[string]string
+
+func (l labels) String() string {
+	var pairs []string
+	for k, v := range l {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l *labels) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("label %q is not in key=value form", value)
+	}
+	if *l == nil {
+		*l = labels{}
+	}
+	(*l)[parts[0]] = parts[1]
+	return nil
+}
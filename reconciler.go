@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// secretReconciler drives the existing sync() logic off Secret events
+// instead of an unconditional polling loop. It only ever reconciles the one
+// (namespace, name) the syncer was configured for.
+type secretReconciler struct {
+	sync syncer
+}
+
+func (r *secretReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	if _, err := r.sync.sync(); err != nil {
+		return reconcile.Result{}, fmt.Errorf("unable to sync certs: %w", err)
+	}
+	return reconcile.Result{}, nil
+}
+
+// secretKey is the single reconcile.Request this binary ever cares about in
+// -watch mode.
+func secretKey(id identifier) types.NamespacedName {
+	return types.NamespacedName{Namespace: id.namespace, Name: id.name}
+}
+
+// watchAndReconcile builds a controller-runtime manager whose Secret cache
+// is scoped, via a field selector, to just the one (namespace, name) being
+// synced - not the whole cluster's Secrets - then reconciles sync whenever
+// that Secret is created, updated or deleted, plus on the manager's resync
+// period. It does one reconcile before the manager starts, so a missing
+// Secret is created immediately instead of waiting for an event that will
+// never come. It blocks until the manager stops or errors.
+func watchAndReconcile(cfg *rest.Config, sync syncer) error {
+	mgr, err := manager.New(cfg, manager.Options{
+		Cache: cache.Options{
+			ByObject: map[client.Object]cache.ByObject{
+				&corev1.Secret{}: {
+					Namespaces: map[string]cache.Config{
+						sync.id.namespace: {
+							FieldSelector: fields.OneTermEqualSelector("metadata.name", sync.id.name),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create controller-runtime manager: %w", err)
+	}
+
+	r := &secretReconciler{sync: sync}
+
+	if err := builder.ControllerManagedBy(mgr).For(&corev1.Secret{}).Complete(r); err != nil {
+		return fmt.Errorf("unable to build controller: %w", err)
+	}
+
+	if _, err := r.Reconcile(context.Background(), reconcile.Request{NamespacedName: secretKey(sync.id)}); err != nil {
+		return fmt.Errorf("unable to do initial sync: %w", err)
+	}
+
+	return mgr.Start(context.Background())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// certServer exposes the CA certificate currently in use and metadata about
+// the last leaf certificate synced, so that clients (CI jobs, init
+// containers) can pin against it without out-of-band file distribution.
+// It returns 503 until the first successful sync has populated it.
+type certServer struct {
+	mu    sync.RWMutex
+	ready bool
+	caPEM string
+	leaf  *x509.Certificate
+}
+
+// update records the CA and leaf certificate from the most recent sync.
+func (c *certServer) update(caPEM, leafPEM string) {
+	var parsedLeaf *x509.Certificate
+	if block, _ := pem.Decode([]byte(leafPEM)); block != nil {
+		parsedLeaf, _ = x509.ParseCertificate(block.Bytes)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.caPEM = caPEM
+	c.leaf = parsedLeaf
+	c.ready = true
+}
+
+func (c *certServer) handleCert(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.ready {
+		http.Error(w, "certificate not yet synced", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-pem-file")
+	fmt.Fprint(w, c.caPEM)
+}
+
+func (c *certServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.ready || c.leaf == nil {
+		http.Error(w, "certificate not yet synced", http.StatusServiceUnavailable)
+		return
+	}
+
+	sans := append([]string(nil), c.leaf.DNSNames...)
+	for _, ip := range c.leaf.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(struct {
+		Subject  string   `json:"subject"`
+		SANs     []string `json:"sans"`
+		NotAfter string   `json:"notAfter"`
+		Serial   string   `json:"serial"`
+	}{
+		Subject:  c.leaf.Subject.CommonName,
+		SANs:     sans,
+		NotAfter: c.leaf.NotAfter.Format("2006-01-02T15:04:05Z07:00"),
+		Serial:   c.leaf.SerialNumber.String(),
+	})
+}
+
+// serve starts an HTTP server in the background exposing the CA certificate
+// at /cert.pem and the current leaf certificate's metadata at /status.
+func (c *certServer) serve(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cert.pem", c.handleCert)
+	mux.HandleFunc("/status", c.handleStatus)
+
+	go func() {
+		log.Printf("Serving certificate endpoint on %s", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Fatalf("Certificate endpoint failed: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	vaultapi "github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Signer produces a leaf certificate for a subject, and can report the CA
+// certificate it is currently signing with so callers can detect CA
+// rotation and build fullchain/ca.crt output without issuing a leaf.
+//
+// readCa backs fileSigner, the original flag-driven behavior; SecretSigner
+// and VaultSigner let the CA come from a Kubernetes Secret or a Vault PKI
+// role instead.
+type Signer interface {
+	Sign(subject string, ip, dns []string, daysValid int) (certPEM, keyPEM string, err error)
+	CACert() (string, error)
+}
+
+// signLeaf generates a key pair and signs it with ca, as the original
+// flag-driven path always did.
+func signLeaf(subject string, ip, dns []string, daysValid int, ca certificate) (string, string, error) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to generate private key: %w", err)
+	}
+	return generateSignedCert(subject, ip, dns, daysValid, rsaKey, ca)
+}
+
+// signerFingerprint hashes a Signer's current CA certificate, for use as
+// an input to inputChecksum so CA rotation forces a resync.
+func signerFingerprint(s Signer) (string, error) {
+	caPEM, err := s.CACert()
+	if err != nil {
+		return "", err
+	}
+	block, _ := pem.Decode([]byte(caPEM))
+	if block == nil {
+		return "", fmt.Errorf("unable to decode CA certificate PEM")
+	}
+	return certFingerprint(block.Bytes), nil
+}
+
+// newSigner picks a Signer based on which CA source flags were set:
+// -ca-vault-path, -ca-secret, or falling back to -cacert/-cakey. secrets is
+// only required when caSecretRef is set.
+func newSigner(cacrtPath, cakeyPath, caSecretRef, caVaultPath string, secrets secretsClient) (Signer, error) {
+	switch {
+	case caVaultPath != "":
+		client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Vault client: %w", err)
+		}
+		mount, role, err := splitVaultRolePath(caVaultPath)
+		if err != nil {
+			return nil, err
+		}
+		return VaultSigner{client: client, mount: mount, role: role}, nil
+
+	case caSecretRef != "":
+		parts := strings.SplitN(caSecretRef, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("-ca-secret must be namespace/name, got %q", caSecretRef)
+		}
+		return SecretSigner{secrets: secrets, id: identifier{namespace: parts[0], name: parts[1]}}, nil
+
+	default:
+		return fileSigner{certPath: cacrtPath, keyPath: cakeyPath}, nil
+	}
+}
+
+// fileSigner reads the CA certificate and key from local files, as tlser
+// has always done by default.
+type fileSigner struct {
+	certPath string
+	keyPath  string
+}
+
+func (f fileSigner) CACert() (string, error) {
+	ca, err := readCa(f.certPath, f.keyPath)
+	if err != nil {
+		return "", err
+	}
+	return encodeCertPEM(ca.cert), nil
+}
+
+func (f fileSigner) Sign(subject string, ip, dns []string, daysValid int) (string, string, error) {
+	ca, err := readCa(f.certPath, f.keyPath)
+	if err != nil {
+		return "", "", err
+	}
+	return signLeaf(subject, ip, dns, daysValid, ca)
+}
+
+// SecretSigner reads the CA certificate and key from a kubernetes.io/tls
+// Secret, re-reading it on every call so CA rotation propagates.
+type SecretSigner struct {
+	secrets secretsClient
+	id      identifier
+}
+
+func (s SecretSigner) load() (certificate, error) {
+	sec, err := s.secrets.getSecret(s.id)
+	if err != nil {
+		return certificate{}, fmt.Errorf("unable to get CA secret %s/%s: %w", s.id.namespace, s.id.name, err)
+	}
+
+	certBlock, _ := pem.Decode(sec.Data[corev1.TLSCertKey])
+	if certBlock == nil {
+		return certificate{}, fmt.Errorf("CA secret %s/%s has no PEM-encoded %s", s.id.namespace, s.id.name, corev1.TLSCertKey)
+	}
+	keyBlock, _ := pem.Decode(sec.Data[corev1.TLSPrivateKeyKey])
+	if keyBlock == nil {
+		return certificate{}, fmt.Errorf("CA secret %s/%s has no PEM-encoded %s", s.id.namespace, s.id.name, corev1.TLSPrivateKeyKey)
+	}
+
+	return parseCertPair(certBlock.Bytes, keyBlock.Bytes)
+}
+
+func (s SecretSigner) CACert() (string, error) {
+	ca, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	return encodeCertPEM(ca.cert), nil
+}
+
+func (s SecretSigner) Sign(subject string, ip, dns []string, daysValid int) (string, string, error) {
+	ca, err := s.load()
+	if err != nil {
+		return "", "", err
+	}
+	return signLeaf(subject, ip, dns, daysValid, ca)
+}
+
+// VaultSigner requests a signed leaf directly from a HashiCorp Vault PKI
+// secrets engine role, rather than holding a CA key-signing locally. It
+// issues against <mount>/issue/<role> and reads the CA from <mount>/ca/pem;
+// <mount>/roles/<role> is only the role's config endpoint and must never be
+// written to or read from directly.
+type VaultSigner struct {
+	client *vaultapi.Client
+	// mount is the PKI secrets engine mount, e.g. "pki".
+	mount string
+	// role is the PKI role to issue against, e.g. "xxx".
+	role string
+}
+
+// splitVaultRolePath splits a -ca-vault-path like "pki/roles/xxx" into its
+// mount ("pki") and role ("xxx"), so callers can issue against
+// <mount>/issue/<role> and read the CA from <mount>/ca/pem instead of
+// writing to the role's config endpoint.
+func splitVaultRolePath(path string) (mount, role string, err error) {
+	idx := strings.Index(path, "/roles/")
+	if idx == -1 {
+		return "", "", fmt.Errorf("-ca-vault-path must look like <mount>/roles/<role>, got %q", path)
+	}
+	return path[:idx], path[idx+len("/roles/"):], nil
+}
+
+func (s VaultSigner) CACert() (string, error) {
+	resp, err := s.client.Logical().ReadRawWithContext(context.Background(), s.mount+"/ca/pem")
+	if err != nil {
+		return "", fmt.Errorf("unable to read Vault CA certificate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("unable to read Vault CA certificate response: %w", err)
+	}
+	return string(body), nil
+}
+
+func (s VaultSigner) Sign(subject string, ip, dns []string, daysValid int) (string, string, error) {
+	data := map[string]interface{}{
+		"common_name": subject,
+		"ttl":         fmt.Sprintf("%dh", daysValid*24),
+	}
+	if len(dns) > 0 {
+		data["alt_names"] = strings.Join(dns, ",")
+	}
+	if len(ip) > 0 {
+		data["ip_sans"] = strings.Join(ip, ",")
+	}
+
+	issuePath := s.mount + "/issue/" + s.role
+	resp, err := s.client.Logical().Write(issuePath, data)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to issue certificate from Vault role %s: %w", issuePath, err)
+	}
+	if resp == nil {
+		return "", "", fmt.Errorf("Vault role %s returned no data", issuePath)
+	}
+
+	certPEM, _ := resp.Data["certificate"].(string)
+	keyPEM, _ := resp.Data["private_key"].(string)
+	if certPEM == "" || keyPEM == "" {
+		return "", "", fmt.Errorf("Vault role %s response missing certificate or private_key", issuePath)
+	}
+	return certPEM, keyPEM, nil
+}
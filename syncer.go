@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// secret is the Kubernetes Secret type we read and write.
+type secret = corev1.Secret
+
+// identifier names a single Secret to keep in sync.
+type identifier struct {
+	name      string
+	namespace string
+}
+
+// secretsClient is the subset of Kubernetes Secret operations the syncer
+// needs; k8sAdapter is the real implementation.
+type secretsClient interface {
+	getSecret(id identifier) (*secret, error)
+	setSecret(secret *secret, update bool) error
+}
+
+// syncer holds everything needed to generate a leaf certificate and keep it
+// in sync with a single Kubernetes Secret.
+type syncer struct {
+	secrets   secretsClient
+	id        identifier
+	subject   string
+	ip        []string
+	dns       []string
+	daysValid int
+	labels    labels
+	getSigner func() (Signer, error)
+
+	// renewBefore is how long before the leaf certificate's notAfter a
+	// sync should rotate it, decoupling expiry-driven rotation from the
+	// polling/resync interval. Zero means 1/3 of daysValid.
+	renewBefore time.Duration
+
+	// formats controls which keys end up in the generated Secret (see
+	// format.go); empty means the original kubernetes.io/tls layout.
+	formats formatList
+	// p12Password is used to encrypt both the PKCS#12 and JKS keystores.
+	p12Password string
+	// opaqueCertKey and opaqueKeyKey name the Data keys used by the
+	// "opaque" format; they default to tls.crt/tls.key if unset.
+	opaqueCertKey, opaqueKeyKey string
+
+	// onSync, if set, is called after each successful sync with the
+	// PEM-encoded CA certificate that was used and the PEM-encoded leaf
+	// certificate that was written. It is used by the -serve endpoint to
+	// keep its in-memory copy fresh.
+	onSync func(caPEM, leafPEM string)
+}
+
+// sync regenerates the leaf certificate if necessary and writes it to the
+// target Secret. It returns the leaf certificate now in effect, so callers
+// that need it (the CRD controller's status update) don't have to read it
+// back through a cache that may not yet reflect what was just written.
+// When the existing Secret is kept unchanged, the leaf is parsed from its
+// tls.crt key; if that key is absent (as with some -format choices), the
+// returned certificate is nil.
+func (s syncer) sync() (*x509.Certificate, error) {
+	signer, err := s.getSigner()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load CA: %w", err)
+	}
+
+	existing, err := s.secrets.getSecret(s.id)
+	update := true
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("unable to get secret %s/%s: %w", s.id.namespace, s.id.name, err)
+		}
+		existing = nil
+		update = false
+	}
+
+	fingerprint, err := signerFingerprint(signer)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fingerprint CA: %w", err)
+	}
+	checksum := inputChecksum(s.subject, s.dns, s.ip, s.daysValid, s.labels, fingerprint, s.formats, s.p12Password, s.opaqueCertKey, s.opaqueKeyKey)
+
+	if existing != nil && existing.Annotations[inputChecksumAnnotation] == checksum {
+		if notAfter, err := time.Parse(time.RFC3339, existing.Annotations[notAfterAnnotation]); err == nil {
+			if time.Until(notAfter) > s.renewBeforeOrDefault() {
+				if s.onSync != nil {
+					caPEM, _ := signer.CACert()
+					s.onSync(caPEM, string(existing.Data[corev1.TLSCertKey]))
+				}
+				leaf, _ := parseLeafCert(existing.Data[corev1.TLSCertKey])
+				return leaf, nil
+			}
+		}
+	}
+
+	certPEM, keyPEM, err := signer.Sign(s.subject, s.ip, s.dns, s.daysValid)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate certificate: %w", err)
+	}
+
+	leaf, err := parseLeafCert([]byte(certPEM))
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse generated leaf certificate: %w", err)
+	}
+
+	caPEM, err := signer.CACert()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load CA certificate: %w", err)
+	}
+
+	data, secretType, err := s.buildSecretData(certPEM, keyPEM, []byte(caPEM))
+	if err != nil {
+		return nil, fmt.Errorf("unable to build secret data: %w", err)
+	}
+
+	newSecret := &secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s.id.name,
+			Namespace: s.id.namespace,
+			Labels:    s.labels,
+			Annotations: map[string]string{
+				inputChecksumAnnotation: checksum,
+				notAfterAnnotation:      leaf.NotAfter.Format(time.RFC3339),
+			},
+		},
+		Type: secretType,
+		Data: data,
+	}
+	if existing != nil {
+		newSecret.ResourceVersion = existing.ResourceVersion
+	}
+
+	if err := s.secrets.setSecret(newSecret, update); err != nil {
+		return nil, err
+	}
+
+	if s.onSync != nil {
+		s.onSync(caPEM, certPEM)
+	}
+	return leaf, nil
+}
+
+func encodeCertPEM(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+// renewBeforeOrDefault returns s.renewBefore, or 1/3 of daysValid if unset.
+func (s syncer) renewBeforeOrDefault() time.Duration {
+	if s.renewBefore != 0 {
+		return s.renewBefore
+	}
+	return time.Duration(s.daysValid) * 24 * time.Hour / 3
+}
+
+// parseLeafCert parses a PEM-encoded leaf certificate out of Secret data.
+func parseLeafCert(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("unable to decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
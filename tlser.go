@@ -4,8 +4,6 @@ package main
 
 import (
 	"context"
-	"crypto/rand"
-	"crypto/rsa"
 	"encoding/pem"
 	"flag"
 	"fmt"
@@ -17,29 +15,59 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
 var (
-	cacrt   = flag.String("cacert", "./ca.pem", "Path to a CA certificate")
-	cakey   = flag.String("cakey", "./ca-key.pem", "Path to a CA private key")
-	subject = flag.String("subject", "", "The certificate Subject Common Name")
-	expire  = flag.Int("expire", 60, "Certificate expiration in days")
-	dns     = flag.String("dns", "", "Comma-separated list of DNS alternative names")
-	ip      = flag.String("ip", "", "Comma-separated list of valid IP addresses")
-
-	k8sName  = flag.String("name", "", "Name of the Kubernetes secret to update")
-	k8sNs    = flag.String("namespace", "", "Namespace of the Kubernetes secret to update")
-	label    = labels{}
-	interval = flag.String("interval", "", "Interval to check if cert is insync (ex: 1h, 30m)")
+	cacrt       = flag.String("cacert", "./ca.pem", "Path to a CA certificate")
+	cakey       = flag.String("cakey", "./ca-key.pem", "Path to a CA private key")
+	caSecret    = flag.String("ca-secret", "", "namespace/name of a Kubernetes Secret to read the CA certificate and key from, instead of -cacert/-cakey")
+	caVaultPath = flag.String("ca-vault-path", "", "Vault PKI role path (ex: pki/roles/xxx) to request signed leaves from, instead of signing locally")
+	subject     = flag.String("subject", "", "The certificate Subject Common Name")
+	expire      = flag.Int("expire", 60, "Certificate expiration in days")
+	dns         = flag.String("dns", "", "Comma-separated list of DNS alternative names")
+	ip          = flag.String("ip", "", "Comma-separated list of valid IP addresses")
+
+	k8sName     = flag.String("name", "", "Name of the Kubernetes secret to update")
+	k8sNs       = flag.String("namespace", "", "Namespace of the Kubernetes secret to update")
+	label       = labels{}
+	interval    = flag.String("interval", "", "Interval to check if cert is insync (ex: 1h, 30m)")
+	serve       = flag.String("serve", "", "Address to serve the CA certificate and sync status on (ex: :8080); disabled if empty")
+	watch       = flag.Bool("watch", false, "Resync in response to Secret events via a controller-runtime watch, instead of polling on -interval")
+	crd         = flag.Bool("crd", false, "Run as a controller watching TLSCertificate custom resources cluster-wide instead of syncing a single flag-defined certificate")
+	renewBefore = flag.String("renew-before", "", "Duration before the certificate's expiry to rotate it (ex: 240h); defaults to 1/3 of -expire")
+
+	format        = formatList{}
+	p12Password   = flag.String("p12-password", "", "Password to encrypt the PKCS#12/JKS keystores with, when -format pkcs12 or -format jks is used")
+	opaqueCertKey = flag.String("opaque-cert-key", "", "Data key for the certificate when -format opaque is used (default tls.crt)")
+	opaqueKeyKey  = flag.String("opaque-key-key", "", "Data key for the private key when -format opaque is used (default tls.key)")
 )
 
 const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
 
 func main() {
 	flag.Var(&label, "label", "Specify a label as key=value to put on the generated secret; can appear repeatedly for multiple labels")
+	flag.Var(&format, "format", "Secret format to produce: pkcs12, jks, fullchain, opaque; can appear repeatedly. When set, only the keys of the listed formats are written")
 	log.SetFlags(0)
 	flag.Parse()
 
+	if *crd {
+		cfg, err := config.GetConfig()
+		if err != nil {
+			log.Fatalf("Unable to get Kubernetes config: %v", err)
+		}
+		mgr, err := manager.New(cfg, manager.Options{})
+		if err != nil {
+			log.Fatalf("Unable to create controller-runtime manager: %v", err)
+		}
+		log.SetFlags(log.LstdFlags)
+		log.Print("Watching TLSCertificate resources cluster-wide")
+		if err := runCRDController(mgr); err != nil {
+			log.Fatalf("Unable to run CRD controller: %v", err)
+		}
+		return
+	}
+
 	if len(*subject) == 0 {
 		log.Fatalf("Missing required -subject parameter")
 	}
@@ -53,6 +81,14 @@ func main() {
 		}
 	}
 
+	var renewBeforeDuration time.Duration
+	if len(*renewBefore) != 0 {
+		renewBeforeDuration, err = time.ParseDuration(*renewBefore)
+		if err != nil {
+			log.Fatalf("Parameter -renew-before was not a valid duration: %v", err)
+		}
+	}
+
 	var ipStrings, dnsStrings []string
 	if len(*ip) > 0 {
 		ipStrings = strings.Split(*ip, ",")
@@ -64,24 +100,25 @@ func main() {
 	if len(*k8sName) == 0 {
 		log.Print("No secret name provided, generating cert on stdout")
 
-		signer, err := readCa(*cacrt, *cakey)
-		if err != nil {
-			log.Fatalf("Failed to read CA files: %v", err)
+		var caSecrets secretsClient
+		if len(*caSecret) != 0 {
+			cfg, err := config.GetConfig()
+			if err != nil {
+				log.Fatalf("Unable to get Kubernetes config: %v", err)
+			}
+			clientset, err := kubernetes.NewForConfig(cfg)
+			if err != nil {
+				log.Fatalf("Unable to initialize Kubernetes client: %v", err)
+			}
+			caSecrets = k8sAdapter{clientset: clientset}
 		}
 
-		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		signer, err := newSigner(*cacrt, *cakey, *caSecret, *caVaultPath, caSecrets)
 		if err != nil {
-			log.Fatalf("Unable to generate private key: %v", err)
+			log.Fatalf("Unable to set up CA signer: %v", err)
 		}
 
-		cert, key, err := generateSignedCert(
-			*subject,
-			ipStrings,
-			dnsStrings,
-			*expire,
-			rsaKey,
-			signer,
-		)
+		cert, key, err := signer.Sign(*subject, ipStrings, dnsStrings, *expire)
 		if err != nil {
 			log.Fatalf("Unable to generate certificate: %v", err)
 		}
@@ -115,20 +152,49 @@ func main() {
 
 	log.Printf("Syncing certificate for %s to secret %s in namespace %s", *subject, *k8sName, namespace)
 	sync := syncer{
-		secrets:   k8sAdapter{clientset: clientset},
-		id:        identifier{name: *k8sName, namespace: namespace},
-		subject:   *subject,
-		ip:        ipStrings,
-		dns:       dnsStrings,
-		daysValid: *expire,
-		labels:    label,
-		getSigner: func() (certificate, error) { return readCa(*cacrt, *cakey) },
+		secrets:       k8sAdapter{clientset: clientset},
+		id:            identifier{name: *k8sName, namespace: namespace},
+		subject:       *subject,
+		ip:            ipStrings,
+		dns:           dnsStrings,
+		daysValid:     *expire,
+		labels:        label,
+		renewBefore:   renewBeforeDuration,
+		formats:       format,
+		p12Password:   *p12Password,
+		opaqueCertKey: *opaqueCertKey,
+		opaqueKeyKey:  *opaqueKeyKey,
+		getSigner: func() (Signer, error) {
+			return newSigner(*cacrt, *cakey, *caSecret, *caVaultPath, k8sAdapter{clientset: clientset})
+		},
+	}
+
+	if len(*serve) != 0 {
+		srv := &certServer{}
+		sync.onSync = srv.update
+		srv.serve(*serve)
+	}
+
+	if *watch {
+		log.Printf("Watching secret %s/%s for changes", namespace, *k8sName)
+		log.SetFlags(log.LstdFlags)
+		if err := watchAndReconcile(cfg, sync); err != nil {
+			log.Fatalf("Unable to watch secret: %v", err)
+		}
+		return
 	}
 
 	if syncInterval == time.Duration(0) {
-		if err := sync.sync(); err != nil {
+		if _, err := sync.sync(); err != nil {
 			log.Fatalf("Unable to sync certs: %v", err)
 		}
+		if len(*serve) != 0 {
+			// -serve started a background HTTP server; without -interval
+			// or -watch there's nothing else to keep the process alive, so
+			// block here instead of exiting and taking it down with us.
+			log.Printf("Certificate synced once, serving it on %s", *serve)
+			select {}
+		}
 		return
 	}
 
@@ -136,7 +202,7 @@ func main() {
 	// Running continously, so add timestamps to log output.
 	log.SetFlags(log.LstdFlags)
 	for {
-		if err := sync.sync(); err != nil {
+		if _, err := sync.sync(); err != nil {
 			log.Fatalf("Unable to sync certs: %v", err)
 		}
 		time.Sleep(syncInterval)